@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/joho/godotenv"
 	"github.com/tebeka/selenium"
+	seleniumlog "github.com/tebeka/selenium/log"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/openai"
 	"golang.org/x/net/html"
@@ -28,11 +30,19 @@ type Review struct {
 	Reviewer string `json:"reviewer"`
 }
 
+// TrafficRecord stores metadata for a single outbound browser request
+type TrafficRecord struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+	Bytes  int64  `json:"bytes"`
+}
+
 // APIResponse represents the standardized API response
 type APIResponse struct {
-	Success bool     `json:"success"`
-	Data    []Review `json:"data,omitempty"`
-	Error   string   `json:"error,omitempty"`
+	Success bool            `json:"success"`
+	Data    []Review        `json:"data,omitempty"`
+	Traffic []TrafficRecord `json:"traffic,omitempty"`
+	Error   string          `json:"error,omitempty"`
 }
 
 // Selenium Configuration
@@ -44,8 +54,10 @@ const (
 
 // ReviewScraper handles the review scraping functionality
 type ReviewScraper struct {
-	llm    llms.LLM
-	driver selenium.WebDriver
+	llm           llms.LLM
+	sessions      []selenium.WebDriver
+	drivers       chan selenium.WebDriver
+	recordTraffic bool
 }
 
 // SeleniumConfig holds the configuration for Selenium connection
@@ -54,6 +66,8 @@ type SeleniumConfig struct {
 	Port          string
 	MaxRetries    int
 	RetryInterval time.Duration
+	RecordTraffic bool
+	MaxSessions   int
 }
 
 // GetSeleniumConfig retrieves Selenium configuration from environment
@@ -63,6 +77,8 @@ func GetSeleniumConfig() SeleniumConfig {
 		Port:          getEnvOrDefault("SELENIUM_PORT", "4444"),
 		MaxRetries:    30, // Will try for 5 minutes
 		RetryInterval: 10 * time.Second,
+		RecordTraffic: getEnvBoolOrDefault("RECORD_TRAFFIC", false),
+		MaxSessions:   getEnvIntOrDefault("MAX_CONCURRENT_SCRAPES", 1),
 	}
 }
 func getEnvOrDefault(key, defaultValue string) string {
@@ -72,6 +88,36 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvBoolOrDefault reads a boolean from the environment
+func getEnvBoolOrDefault(key string, defaultValue bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf("Warning: ignoring invalid %s=%q, expected a boolean; using %t", key, raw, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvIntOrDefault reads a positive integer from the environment
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		log.Printf("Warning: ignoring invalid %s=%q, expected a positive integer; using %d", key, raw, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
 // waitForSelenium waits for Selenium to be ready
 func waitForSelenium(config SeleniumConfig) error {
 	seleniumURL := fmt.Sprintf("http://%s:%s/wd/hub/status", config.Host, config.Port)
@@ -136,44 +182,159 @@ func NewReviewScraper() (*ReviewScraper, error) {
 		},
 	}
 
-	// Connect to Selenium with retries
-	var driver selenium.WebDriver
+	// Enable network logging when traffic recording is requested
+	if seleniumConfig.RecordTraffic {
+		caps[seleniumlog.CapabilitiesKey] = seleniumlog.Capabilities{
+			seleniumlog.Performance: seleniumlog.All,
+		}
+	}
+
+	rs := &ReviewScraper{
+		llm:           llm,
+		drivers:       make(chan selenium.WebDriver, seleniumConfig.MaxSessions),
+		recordTraffic: seleniumConfig.RecordTraffic,
+	}
+
+	// Open one browser session per allowed concurrent scrape. Only the first
+	// session retries; once Selenium is up, a failure to open further sessions
+	// means the node is out of capacity, so fail fast instead of waiting.
+	for i := 0; i < seleniumConfig.MaxSessions; i++ {
+		attempts := seleniumConfig.MaxRetries
+		if i > 0 {
+			attempts = 1
+		}
+
+		driver, err := connectSelenium(seleniumConfig, caps, attempts)
+		if err != nil {
+			rs.Close()
+			if i > 0 {
+				return nil, fmt.Errorf("failed to open browser session %d of %d (MAX_CONCURRENT_SCRAPES may exceed the Selenium node's free sessions): %v",
+					i+1, seleniumConfig.MaxSessions, err)
+			}
+			return nil, err
+		}
+		rs.sessions = append(rs.sessions, driver)
+		rs.drivers <- driver
+	}
+
+	return rs, nil
+}
+
+// connectSelenium opens a new browser session, trying up to the given number of attempts
+func connectSelenium(config SeleniumConfig, caps selenium.Capabilities, attempts int) (selenium.WebDriver, error) {
 	var lastErr error
 
-	for i := 0; i < seleniumConfig.MaxRetries; i++ {
-		driver, err = selenium.NewRemote(
+	for i := 0; i < attempts; i++ {
+		driver, err := selenium.NewRemote(
 			caps,
 			fmt.Sprintf("http://%s:%s/wd/hub",
-				seleniumConfig.Host,
-				seleniumConfig.Port,
+				config.Host,
+				config.Port,
 			),
 		)
 		if err == nil {
-			break
+			return driver, nil
 		}
 		lastErr = err
-		log.Printf("Failed to connect to Selenium (attempt %d/%d): %v", i+1, seleniumConfig.MaxRetries, err)
-		time.Sleep(seleniumConfig.RetryInterval)
-	}
-
-	if driver == nil {
-		return nil, fmt.Errorf("failed to connect to Selenium after %d attempts: %v",
-			seleniumConfig.MaxRetries, lastErr)
+		log.Printf("Failed to connect to Selenium (attempt %d/%d): %v", i+1, attempts, err)
+		if i+1 < attempts {
+			time.Sleep(config.RetryInterval)
+		}
 	}
 
-	return &ReviewScraper{
-		llm:    llm,
-		driver: driver,
-	}, nil
+	return nil, fmt.Errorf("failed to connect to Selenium after %d attempts: %v",
+		attempts, lastErr)
 }
 
 // Close cleans up resources
 func (rs *ReviewScraper) Close() {
-	if rs.driver != nil {
-		rs.driver.Quit()
+	for _, driver := range rs.sessions {
+		driver.Quit()
 	}
 }
 
+// parseTrafficLog converts Chrome performance log entries into traffic records.
+// Redirects reuse the same request ID, so each hop is recorded separately.
+func parseTrafficLog(messages []seleniumlog.Message) []TrafficRecord {
+	type logResponse struct {
+		URL               string  `json:"url"`
+		Status            int     `json:"status"`
+		EncodedDataLength float64 `json:"encodedDataLength"`
+	}
+	type logEntry struct {
+		Message struct {
+			Method string `json:"method"`
+			Params struct {
+				RequestID string `json:"requestId"`
+				Request   struct {
+					URL string `json:"url"`
+				} `json:"request"`
+				Response          logResponse  `json:"response"`
+				RedirectResponse  *logResponse `json:"redirectResponse"`
+				EncodedDataLength float64      `json:"encodedDataLength"`
+			} `json:"params"`
+		} `json:"message"`
+	}
+
+	var records []TrafficRecord
+	current := make(map[string]int)
+	startHop := func(id string) int {
+		records = append(records, TrafficRecord{})
+		current[id] = len(records) - 1
+		return current[id]
+	}
+	recordFor := func(id string) int {
+		if i, ok := current[id]; ok {
+			return i
+		}
+		return startHop(id)
+	}
+
+	for _, msg := range messages {
+		var entry logEntry
+		if err := json.Unmarshal([]byte(msg.Message), &entry); err != nil {
+			continue
+		}
+
+		params := entry.Message.Params
+		switch entry.Message.Method {
+		case "Network.requestWillBeSent":
+			i := recordFor(params.RequestID)
+			if redirect := params.RedirectResponse; redirect != nil {
+				// Finish the previous hop before following the redirect
+				records[i].URL = redirect.URL
+				records[i].Status = redirect.Status
+				records[i].Bytes = int64(redirect.EncodedDataLength)
+				i = startHop(params.RequestID)
+			}
+			records[i].URL = params.Request.URL
+		case "Network.responseReceived":
+			i := recordFor(params.RequestID)
+			records[i].URL = params.Response.URL
+			records[i].Status = params.Response.Status
+		case "Network.loadingFinished":
+			records[recordFor(params.RequestID)].Bytes = int64(params.EncodedDataLength)
+		}
+	}
+
+	traffic := make([]TrafficRecord, 0, len(records))
+	for _, r := range records {
+		if r.URL != "" {
+			traffic = append(traffic, r)
+		}
+	}
+	return traffic
+}
+
+// collectTraffic drains the browser performance log into traffic records
+func collectTraffic(driver selenium.WebDriver) ([]TrafficRecord, error) {
+	messages, err := driver.Log(seleniumlog.Performance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read performance log: %v", err)
+	}
+	return parseTrafficLog(messages), nil
+}
+
 // findReviewIDs finds all IDs containing "review" in their attributes
 func findReviewIDs(html string) []string {
 	pattern := `(?i)id=["']([^"'\s]*review[s]?[^"']*)["']`
@@ -268,10 +429,10 @@ JSON format:
 }
 
 // handlePagination handles pagination for review extraction
-func (rs *ReviewScraper) handlePagination(processPage func(pageSource string) error) error {
+func handlePagination(driver selenium.WebDriver, processPage func(pageSource string) error) error {
 	prevPageSource := ""
 	for {
-		pageSource, err := rs.driver.PageSource()
+		pageSource, err := driver.PageSource()
 		if err != nil {
 			return fmt.Errorf("failed to fetch page source: %v", err)
 		}
@@ -291,7 +452,7 @@ func (rs *ReviewScraper) handlePagination(processPage func(pageSource string) er
 		var nextButton selenium.WebElement
 		found := false
 		for _, selector := range nextSelectors {
-			nextButton, err = rs.driver.FindElement(selenium.ByCSSSelector, selector)
+			nextButton, err = driver.FindElement(selenium.ByCSSSelector, selector)
 			if err == nil {
 				found = true
 				break
@@ -299,14 +460,14 @@ func (rs *ReviewScraper) handlePagination(processPage func(pageSource string) er
 		}
 
 		if !found {
-			_, err = rs.driver.ExecuteScript("window.scrollTo(0, document.body.scrollHeight);", nil)
+			_, err = driver.ExecuteScript("window.scrollTo(0, document.body.scrollHeight);", nil)
 			if err != nil {
 				return fmt.Errorf("failed to scroll: %v", err)
 			}
 
 			time.Sleep(2 * time.Second)
 
-			newPageSource, err := rs.driver.PageSource()
+			newPageSource, err := driver.PageSource()
 			if err != nil {
 				return fmt.Errorf("failed to fetch new page source: %v", err)
 			}
@@ -326,20 +487,45 @@ func (rs *ReviewScraper) handlePagination(processPage func(pageSource string) er
 	return nil
 }
 
-// ScrapeReviews scrapes reviews from the given URL
-func (rs *ReviewScraper) ScrapeReviews(url string) ([]Review, error) {
-	if err := rs.driver.Get(url); err != nil {
-		return nil, fmt.Errorf("failed to load page: %v", err)
+// ScrapeReviews scrapes reviews from the given URL, returning the recorded
+// browser traffic when recording is enabled. Traffic is returned even when
+// the scrape fails, since any pages loaded before the failure were accessed.
+func (rs *ReviewScraper) ScrapeReviews(url string) (allReviews []Review, traffic []TrafficRecord, err error) {
+	// Wait for a free browser session
+	driver := <-rs.drivers
+	defer func() { rs.drivers <- driver }()
+
+	if rs.recordTraffic {
+		// Discard entries left over from earlier requests
+		if _, err := collectTraffic(driver); err != nil {
+			return nil, nil, err
+		}
+
+		defer func() {
+			recorded, collectErr := collectTraffic(driver)
+			if collectErr != nil {
+				// A missing audit trail must not look like a successful scrape
+				if err == nil {
+					err = collectErr
+				} else {
+					log.Printf("Failed to record traffic for %s: %v", url, collectErr)
+				}
+				return
+			}
+			traffic = recorded
+		}()
 	}
 
-	err := rs.driver.SetImplicitWaitTimeout(pageLoadTimeout)
-	if err != nil {
-		return nil, fmt.Errorf("failed to set implicit wait: %v", err)
+	if err := driver.Get(url); err != nil {
+		return nil, nil, fmt.Errorf("failed to load page: %v", err)
 	}
 
-	var allReviews []Review
+	err = driver.SetImplicitWaitTimeout(pageLoadTimeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set implicit wait: %v", err)
+	}
 
-	err = rs.handlePagination(func(pageSource string) error {
+	err = handlePagination(driver, func(pageSource string) error {
 		reviewIDs := findReviewIDs(pageSource)
 
 		if len(reviewIDs) == 0 {
@@ -373,10 +559,10 @@ func (rs *ReviewScraper) ScrapeReviews(url string) ([]Review, error) {
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("error during pagination: %v", err)
+		return nil, nil, fmt.Errorf("error during pagination: %v", err)
 	}
 
-	return allReviews, nil
+	return allReviews, nil, nil
 }
 
 // setupRoutes sets up the API routes
@@ -390,10 +576,12 @@ func setupRoutes(app *fiber.App, scraper *ReviewScraper) {
 			})
 		}
 
-		reviews, err := scraper.ScrapeReviews(url)
+		reviews, traffic, err := scraper.ScrapeReviews(url)
 		if err != nil {
 			return c.JSON(APIResponse{
 				Success: false,
+				Data:    reviews,
+				Traffic: traffic,
 				Error:   err.Error(),
 			})
 		}
@@ -401,6 +589,7 @@ func setupRoutes(app *fiber.App, scraper *ReviewScraper) {
 		return c.JSON(APIResponse{
 			Success: true,
 			Data:    reviews,
+			Traffic: traffic,
 		})
 	})
 }
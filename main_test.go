@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tebeka/selenium"
+	seleniumlog "github.com/tebeka/selenium/log"
+)
+
+// fakeDriver serves an empty page with no pagination and fails performance
+// log reads after the first one
+type fakeDriver struct {
+	selenium.WebDriver
+	logCalls int
+}
+
+func (d *fakeDriver) Get(string) error                           { return nil }
+func (d *fakeDriver) SetImplicitWaitTimeout(time.Duration) error { return nil }
+func (d *fakeDriver) PageSource() (string, error)                { return "", nil }
+
+func (d *fakeDriver) FindElement(string, string) (selenium.WebElement, error) {
+	return nil, errors.New("no such element")
+}
+
+func (d *fakeDriver) ExecuteScript(string, []interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func (d *fakeDriver) Log(seleniumlog.Type) ([]seleniumlog.Message, error) {
+	d.logCalls++
+	if d.logCalls > 1 {
+		return nil, errors.New("session closed")
+	}
+	return nil, nil
+}
+
+func performanceLog(entries ...string) []seleniumlog.Message {
+	messages := make([]seleniumlog.Message, len(entries))
+	for i, entry := range entries {
+		messages[i] = seleniumlog.Message{Level: seleniumlog.Info, Message: entry}
+	}
+	return messages
+}
+
+func TestParseTrafficLog(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []seleniumlog.Message
+		want     []TrafficRecord
+	}{
+		{
+			name: "completed response",
+			messages: performanceLog(
+				`{"message":{"method":"Network.requestWillBeSent","params":{"requestId":"1","request":{"url":"https://example.com/product"}}}}`,
+				`{"message":{"method":"Network.responseReceived","params":{"requestId":"1","response":{"url":"https://example.com/product","status":200}}}}`,
+				`{"message":{"method":"Network.loadingFinished","params":{"requestId":"1","encodedDataLength":48213}}}`,
+			),
+			want: []TrafficRecord{
+				{URL: "https://example.com/product", Status: 200, Bytes: 48213},
+			},
+		},
+		{
+			name: "failed request without response",
+			messages: performanceLog(
+				`{"message":{"method":"Network.requestWillBeSent","params":{"requestId":"2","request":{"url":"https://cdn.example.com/app.js"}}}}`,
+				`{"message":{"method":"Network.loadingFailed","params":{"requestId":"2","errorText":"net::ERR_NAME_NOT_RESOLVED"}}}`,
+			),
+			want: []TrafficRecord{
+				{URL: "https://cdn.example.com/app.js", Status: 0, Bytes: 0},
+			},
+		},
+		{
+			name: "redirect chain",
+			messages: performanceLog(
+				`{"message":{"method":"Network.requestWillBeSent","params":{"requestId":"3","request":{"url":"http://example.com/p"}}}}`,
+				`{"message":{"method":"Network.requestWillBeSent","params":{"requestId":"3","request":{"url":"https://example.com/p"},"redirectResponse":{"url":"http://example.com/p","status":301,"encodedDataLength":312}}}}`,
+				`{"message":{"method":"Network.requestWillBeSent","params":{"requestId":"3","request":{"url":"https://example.com/product"},"redirectResponse":{"url":"https://example.com/p","status":302,"encodedDataLength":287}}}}`,
+				`{"message":{"method":"Network.responseReceived","params":{"requestId":"3","response":{"url":"https://example.com/product","status":200}}}}`,
+				`{"message":{"method":"Network.loadingFinished","params":{"requestId":"3","encodedDataLength":1024}}}`,
+			),
+			want: []TrafficRecord{
+				{URL: "http://example.com/p", Status: 301, Bytes: 312},
+				{URL: "https://example.com/p", Status: 302, Bytes: 287},
+				{URL: "https://example.com/product", Status: 200, Bytes: 1024},
+			},
+		},
+		{
+			name: "malformed entry skipped",
+			messages: performanceLog(
+				`{"message":{"method":"Network.requestWillBeSent"`,
+				`{"message":{"method":"Network.responseReceived","params":{"requestId":"4","response":{"url":"https://example.com/reviews","status":200}}}}`,
+			),
+			want: []TrafficRecord{
+				{URL: "https://example.com/reviews", Status: 200, Bytes: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseTrafficLog(tt.messages)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTrafficLog() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrapeReviewsTrafficCollectionFailure(t *testing.T) {
+	driver := &fakeDriver{}
+	rs := &ReviewScraper{
+		drivers:       make(chan selenium.WebDriver, 1),
+		recordTraffic: true,
+	}
+	rs.drivers <- driver
+
+	_, traffic, err := rs.ScrapeReviews("https://example.com/product")
+	if err == nil {
+		t.Fatal("ScrapeReviews() error = nil, want traffic collection error")
+	}
+	if traffic != nil {
+		t.Errorf("ScrapeReviews() traffic = %+v, want nil", traffic)
+	}
+	if driver.logCalls != 2 {
+		t.Errorf("performance log read %d times, want 2", driver.logCalls)
+	}
+}